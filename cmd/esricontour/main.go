@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/goblimey/esrigrid/contour"
+	"github.com/goblimey/esrigrid/model"
+)
+
+var filename string // The Esri grid data file.
+var output string   // The results file.
+var levelsArg string // Comma separated list of contour levels.
+var format string   // Output format - svg or geojson.
+var verbose bool    // verbose mode
+
+func init() {
+	flag.StringVar(&filename, "input", "", "Esri grid data file")
+	flag.StringVar(&filename, "i", "", "Esri grid data file")
+	flag.StringVar(&output, "output", "", "results file")
+	flag.StringVar(&output, "o", "", "results file")
+	flag.StringVar(&levelsArg, "levels", "", "comma separated list of contour elevations")
+	flag.StringVar(&format, "format", "svg", "output format - svg or geojson")
+	flag.BoolVar(&verbose, "verbose", false, "verbose mode")
+	flag.BoolVar(&verbose, "v", false, "verbose mode")
+}
+
+func main() {
+	flag.Parse()
+
+	levels, err := parseLevels(levelsArg)
+	if err != nil {
+		log.Printf(err.Error())
+		return
+	}
+
+	out, err := os.Create(output)
+	if err != nil {
+		log.Printf(err.Error())
+		return
+	}
+	defer out.Close()
+
+	g := model.MakeEsriGrid()
+	err = g.ReadEsriGridFromFile(filename, verbose)
+	if err != nil {
+		log.Printf(err.Error())
+		return
+	}
+
+	lines := contour.ContoursByLevel(g, levels)
+	if verbose {
+		log.Printf("esricontour: found %d polylines across %d levels", len(lines), len(levels))
+	}
+
+	switch format {
+	case "geojson":
+		err = writeGeoJSON(out, lines)
+	default:
+		err = writeSVG(out, g, lines)
+	}
+	if err != nil {
+		log.Printf(err.Error())
+	}
+}
+
+// parseLevels parses the comma separated --levels value into a slice of float32 elevations.
+func parseLevels(arg string) ([]float32, error) {
+	if strings.TrimSpace(arg) == "" {
+		return nil, fmt.Errorf("no contour levels given - use --levels")
+	}
+	fields := strings.Split(arg, ",")
+	levels := make([]float32, 0, len(fields))
+	for _, field := range fields {
+		v, err := strconv.ParseFloat(strings.TrimSpace(field), 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid contour level %q", field)
+		}
+		levels = append(levels, float32(v))
+	}
+	return levels, nil
+}
+
+// writeSVG writes the contour lines as an SVG document, one <path> per polyline, coloured and
+// grouped per level.
+func writeSVG(out *os.File, g model.EsriGrid, lines []contour.LeveledPolyline) error {
+	width := float32(g.Ncols()) * g.CellSize()
+	height := float32(g.Nrows()) * g.CellSize()
+
+	fmt.Fprintf(out, "<svg xmlns=\"http://www.w3.org/2000/svg\" viewBox=\"0 0 %f %f\">\n", width, height)
+
+	byLevel := map[float32][]contour.Polyline{}
+	var levelOrder []float32
+	for _, lp := range lines {
+		if _, ok := byLevel[lp.Level]; !ok {
+			levelOrder = append(levelOrder, lp.Level)
+		}
+		byLevel[lp.Level] = append(byLevel[lp.Level], lp.Line)
+	}
+
+	for i, level := range levelOrder {
+		fmt.Fprintf(out, "  <g class=\"contour\" data-level=\"%g\" stroke=\"%s\" fill=\"none\">\n",
+			level, svgColorForLevel(i))
+		for _, line := range byLevel[level] {
+			fmt.Fprintf(out, "    <path d=\"%s\"/>\n", svgPathData(g, line))
+		}
+		fmt.Fprintln(out, "  </g>")
+	}
+
+	fmt.Fprintln(out, "</svg>")
+	return nil
+}
+
+// svgPathData renders a polyline as SVG path data, flipping Y since SVG's origin is top left
+// and the grid's map coordinates increase northward.
+func svgPathData(g model.EsriGrid, line contour.Polyline) string {
+	var b strings.Builder
+	height := float32(g.Nrows()) * g.CellSize()
+	for i, p := range line {
+		x := p.X - g.Xllcorner()
+		y := height - (p.Y - g.Yllcorner())
+		if i == 0 {
+			fmt.Fprintf(&b, "M %f %f", x, y)
+		} else {
+			fmt.Fprintf(&b, " L %f %f", x, y)
+		}
+	}
+	return b.String()
+}
+
+// svgColorForLevel picks the colour for the index'th contour level written, cycling through a
+// fixed palette so that adjacent levels in the output are visually distinguishable.
+func svgColorForLevel(index int) string {
+	colors := []string{"#8b4513", "#a0522d", "#b8860b", "#6b8e23", "#4682b4"}
+	return colors[index%len(colors)]
+}
+
+// geoJSONFeatureCollection and geoJSONFeature mirror the minimal subset of the GeoJSON spec
+// needed for a MultiLineString FeatureCollection.
+type geoJSONFeatureCollection struct {
+	Type     string            `json:"type"`
+	Features []geoJSONFeature  `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Properties map[string]interface{} `json:"properties"`
+	Geometry   geoJSONGeometry        `json:"geometry"`
+}
+
+type geoJSONGeometry struct {
+	Type        string         `json:"type"`
+	Coordinates [][][2]float32 `json:"coordinates"`
+}
+
+// writeGeoJSON writes the contour lines as a GeoJSON FeatureCollection of MultiLineString
+// features, one feature per contour level.
+func writeGeoJSON(out *os.File, lines []contour.LeveledPolyline) error {
+	byLevel := map[float32][][][2]float32{}
+	var levelOrder []float32
+	for _, lp := range lines {
+		if _, ok := byLevel[lp.Level]; !ok {
+			levelOrder = append(levelOrder, lp.Level)
+		}
+		coords := make([][2]float32, len(lp.Line))
+		for i, p := range lp.Line {
+			coords[i] = [2]float32{p.X, p.Y}
+		}
+		byLevel[lp.Level] = append(byLevel[lp.Level], coords)
+	}
+
+	fc := geoJSONFeatureCollection{Type: "FeatureCollection"}
+	for _, level := range levelOrder {
+		fc.Features = append(fc.Features, geoJSONFeature{
+			Type:       "Feature",
+			Properties: map[string]interface{}{"level": level},
+			Geometry: geoJSONGeometry{
+				Type:        "MultiLineString",
+				Coordinates: byLevel[level],
+			},
+		})
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(fc)
+}
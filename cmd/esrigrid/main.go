@@ -0,0 +1,318 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"github.com/goblimey/esrigrid/model"
+	"image"
+	"image/color"
+	"image/png"
+	"log"
+	"os"
+)
+
+var errUnsupportedGrid = errors.New("this rendering mode requires a grid loaded by model.MakeEsriGrid")
+var errNoRampFile = errors.New("color-relief mode requires a --ramp file")
+var errUnknownResampleMethod = errors.New("--resample-method must be nearest, bilinear or bicubic")
+var errCRSNotSupported = errors.New("--crs requires a model.CoordTransform implementation; this tool has none built in, so reprojection is only available to callers of model.ConcreteEsriGrid.Reproject")
+
+var filename string   // The point cloud file to display.
+var output string     // The .png results file.
+var ceiling64 float64 // parameter - the maximum height expected.
+var ceiling float32   // ceiling as a float32
+var floor64 float64   // parameter - the minimum height expected.
+var floor float32     // floor as a float32
+var verbose bool      // verbose mode
+var mode string        // rendering mode - height, hillshade, ...
+
+var azimuth64 float64 // parameter - sun azimuth in degrees for hillshade mode.
+var altitude64 float64 // parameter - sun altitude in degrees for hillshade mode.
+var zFactor64 float64 // parameter - vertical exaggeration for hillshade and slope modes.
+
+var ramp string         // color-relief mode - the .clr colour ramp file.
+var rampDiscrete bool   // color-relief mode - use nearest-entry colour instead of interpolating.
+
+var gridOutput string // slope/aspect mode - optional path to write the derived grid as ASCII Esri grid.
+
+var resampleCellSize float64 // parameter - if non-zero, resample the grid to this cell size before rendering.
+var resampleMethod string    // resample mode - nearest, bilinear or bicubic.
+var crs string                // optional target CRS, e.g. "EPSG:27700" - see errCRSNotSupported.
+var geoTIFFOutput string      // optional path to write the (possibly resampled) grid as a GeoTIFF.
+
+var maxHeight float64 = 0
+var maxHeightSupplied = false	// true if the maxHeight was supplied on the command line.
+var minHeight float64 = 0
+var minHeightSupplied = false	// true if the minHeight was supplied on the command line.
+var NUMBER_OF_SHADES = 256;		// Number of shades of grey available.
+
+func init() {
+	flag.StringVar(&filename, "input", "", "point cloud data file")
+	flag.StringVar(&filename, "i", "", "point cloud data file")
+	flag.StringVar(&output, "output", "", ".png results file")
+	flag.StringVar(&output, "o", "", ".png results file")
+	flag.Float64Var(&ceiling64, "ceiling", 0.0, "maximum height expected")
+	flag.Float64Var(&ceiling64, "c", 0.0, "maximum height expected")
+	flag.Float64Var(&floor64, "floor", 0.0, "mimimum height expected")
+	flag.Float64Var(&floor64, "f", 0.0, "minimum height expected")
+	flag.BoolVar(&verbose, "verbose", false, "verbose mode")
+	flag.BoolVar(&verbose, "v", false, "verbose mode")
+	flag.StringVar(&mode, "mode", "height", "rendering mode - height, hillshade, color-relief, slope or aspect")
+	flag.Float64Var(&azimuth64, "azimuth", 315.0, "hillshade mode - sun azimuth in degrees, 0 is north, clockwise")
+	flag.Float64Var(&altitude64, "altitude", 45.0, "hillshade mode - sun altitude in degrees above the horizon")
+	flag.Float64Var(&zFactor64, "z-factor", 1.0, "hillshade and slope modes - vertical exaggeration factor")
+	flag.StringVar(&ramp, "ramp", "", "color-relief mode - the colour ramp file")
+	flag.BoolVar(&rampDiscrete, "ramp-discrete", false, "color-relief mode - use the nearest ramp entry instead of interpolating")
+	flag.StringVar(&gridOutput, "grid-output", "", "slope/aspect mode - optional path to write the derived grid as an ASCII Esri grid")
+	flag.Float64Var(&resampleCellSize, "resample-cellsize", 0.0, "if non-zero, resample the grid to this cell size before rendering")
+	flag.StringVar(&resampleMethod, "resample-method", "bilinear", "resampling method to use with --resample-cellsize - nearest, bilinear or bicubic")
+	flag.StringVar(&crs, "crs", "", "stub - not implemented yet: this tool has no built-in model.CoordTransform to reproject with, so any value here is rejected (see errCRSNotSupported). model.ConcreteEsriGrid.Reproject is usable from Go with your own CoordTransform")
+	flag.StringVar(&geoTIFFOutput, "geotiff-output", "", "optional path to write the (possibly resampled) grid as a GeoTIFF")
+}
+
+func main() {
+
+	// Get the command line arguments.
+	flag.Parse()
+
+	// flagset contains the names of the flags that were supplied on the command line.
+	flagset := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { flagset[f.Name] = true })
+
+	// Create the output .png file.
+	out, err := os.Create(output)
+	if err != nil {
+		log.Printf(err.Error())
+		return
+	}
+
+	// Create an esrigrid object from the given data file.
+	pc := model.MakeEsriGrid()
+	err = pc.ReadEsriGridFromFile(filename, verbose)
+	if err != nil {
+		log.Printf(err.Error())
+		return
+	}
+
+	if crs != "" {
+		log.Printf(errCRSNotSupported.Error())
+		return
+	}
+
+	if resampleCellSize != 0 {
+		pc, err = resample(pc)
+		if err != nil {
+			log.Printf(err.Error())
+			return
+		}
+	}
+
+	if geoTIFFOutput != "" {
+		ceg, ok := pc.(*model.ConcreteEsriGrid)
+		if !ok {
+			log.Printf(errUnsupportedGrid.Error())
+			return
+		}
+		if err = ceg.WriteGeoTIFF(geoTIFFOutput); err != nil {
+			log.Printf(err.Error())
+			return
+		}
+	}
+
+	// If the floor is not already set from the command line, set it from the object.
+	if !(flagset["floor"] || flagset["f"]) {
+		floor = pc.MinHeight()
+	}
+
+	// If the ceiling is not already set from the command line, set it from the object.
+	if !(flagset["ceiling"] || flagset["f"]) {
+		ceiling = pc.MaxHeight()
+	}
+
+	var img *image.RGBA
+
+	switch mode {
+	case "hillshade":
+		img, err = renderHillshade(pc)
+		if err != nil {
+			log.Printf(err.Error())
+			return
+		}
+	case "color-relief":
+		img, err = renderColorRelief(pc)
+		if err != nil {
+			log.Printf(err.Error())
+			return
+		}
+	case "slope":
+		var derived model.EsriGrid
+		img, derived, err = renderSlope(pc)
+		if err != nil {
+			log.Printf(err.Error())
+			return
+		}
+		if gridOutput != "" {
+			if err = derived.WriteEsriGridToFile(gridOutput); err != nil {
+				log.Printf(err.Error())
+				return
+			}
+		}
+	case "aspect":
+		var derived model.EsriGrid
+		img, derived, err = renderAspect(pc)
+		if err != nil {
+			log.Printf(err.Error())
+			return
+		}
+		if gridOutput != "" {
+			if err = derived.WriteEsriGridToFile(gridOutput); err != nil {
+				log.Printf(err.Error())
+				return
+			}
+		}
+	default:
+		img = renderHeight(pc)
+	}
+
+	// Write the RGBA to the PNG image file.
+	err = png.Encode(out, img)
+}
+
+// resample resamples pc to resampleCellSize using the method named by resampleMethod.
+func resample(pc model.EsriGrid) (model.EsriGrid, error) {
+	ceg, ok := pc.(*model.ConcreteEsriGrid)
+	if !ok {
+		return nil, errUnsupportedGrid
+	}
+
+	var method model.ResampleMethod
+	switch resampleMethod {
+	case "nearest":
+		method = model.Nearest
+	case "bilinear":
+		method = model.Bilinear
+	case "bicubic":
+		method = model.Bicubic
+	default:
+		return nil, errUnknownResampleMethod
+	}
+
+	return ceg.Resample(float32(resampleCellSize), method), nil
+}
+
+// renderHeight creates an RGBA image with one pixel per grid cell, each shaded with a grey
+// level proportional to its height between floor and ceiling.  The origin is at the top left,
+// same as the grid.
+func renderHeight(pc model.EsriGrid) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, pc.Nrows(), pc.Ncols()))
+	for row := 0; row < pc.Nrows(); row++ {
+		for col := 0; col < pc.Ncols(); col++ {
+			s := shade(floor, ceiling, pc.Height(row, col))
+			if verbose {
+				log.Printf("shading cell[%d[%d] %d\n", row, col, s)
+			}
+			img.Set(col, row, s)
+		}
+	}
+	return img
+}
+
+// renderHillshade creates an RGBA image with one pixel per grid cell, each shaded according to
+// Horn's hillshade algorithm.  Cells that have no shade value (the edges of the grid, or cells
+// whose neighbourhood touches the NoData value) are rendered fully transparent.
+func renderHillshade(pc model.EsriGrid) (*image.RGBA, error) {
+	ceg, ok := pc.(*model.ConcreteEsriGrid)
+	if !ok {
+		return nil, errUnsupportedGrid
+	}
+
+	hs := ceg.Hillshade(float32(azimuth64), float32(altitude64), float32(zFactor64))
+
+	img := image.NewRGBA(image.Rect(0, 0, hs.Ncols(), hs.Nrows()))
+	for row := 0; row < hs.Nrows(); row++ {
+		for col := 0; col < hs.Ncols(); col++ {
+			s := hs.Height(row, col)
+			if s == hs.NoDataValue() {
+				img.Set(col, row, color.RGBA{0, 0, 0, 0})
+				continue
+			}
+			g := uint8(s)
+			img.Set(col, row, color.RGBA{g, g, g, 255})
+		}
+	}
+	return img, nil
+}
+
+// renderColorRelief creates an RGBA image with one pixel per grid cell, each coloured
+// according to the colour ramp loaded from the --ramp file.
+func renderColorRelief(pc model.EsriGrid) (*image.RGBA, error) {
+	if ramp == "" {
+		return nil, errNoRampFile
+	}
+	cr, err := model.ReadColorRampFromFile(ramp)
+	if err != nil {
+		return nil, err
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, pc.Ncols(), pc.Nrows()))
+	for row := 0; row < pc.Nrows(); row++ {
+		for col := 0; col < pc.Ncols(); col++ {
+			img.Set(col, row, cr.Color(pc.Height(row, col), pc.NoDataValue(), rampDiscrete))
+		}
+	}
+	return img, nil
+}
+
+// renderSlope creates an RGBA image with one pixel per grid cell, shaded with a grey level
+// proportional to the cell's slope (0 degrees is black, 90 degrees is white).  It also returns
+// the derived slope grid so that it can optionally be written back out to an ASCII Esri file.
+func renderSlope(pc model.EsriGrid) (*image.RGBA, model.EsriGrid, error) {
+	ceg, ok := pc.(*model.ConcreteEsriGrid)
+	if !ok {
+		return nil, nil, errUnsupportedGrid
+	}
+	slope := ceg.Slope(float32(zFactor64))
+	return renderGrayscaleDerived(slope, 0, 90), slope, nil
+}
+
+// renderAspect creates an RGBA image with one pixel per grid cell, shaded with a grey level
+// proportional to the cell's aspect (0 degrees, north, is black; 360 degrees is white).  Flat
+// cells (aspect -1) are rendered the same as NoData - fully transparent.  It also returns the
+// derived aspect grid so that it can optionally be written back out to an ASCII Esri file.
+func renderAspect(pc model.EsriGrid) (*image.RGBA, model.EsriGrid, error) {
+	ceg, ok := pc.(*model.ConcreteEsriGrid)
+	if !ok {
+		return nil, nil, errUnsupportedGrid
+	}
+	aspect := ceg.Aspect()
+	return renderGrayscaleDerived(aspect, 0, 360), aspect, nil
+}
+
+// renderGrayscaleDerived creates an RGBA image with one pixel per grid cell, shaded with a grey
+// level proportional to where the cell's value falls between min and max.  NoData cells (and,
+// for aspect grids, flat cells with a value of -1) are rendered fully transparent.
+func renderGrayscaleDerived(g model.EsriGrid, min, max float32) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, g.Ncols(), g.Nrows()))
+	for row := 0; row < g.Nrows(); row++ {
+		for col := 0; col < g.Ncols(); col++ {
+			v := g.Height(row, col)
+			if v == g.NoDataValue() || v < 0 {
+				img.Set(col, row, color.RGBA{0, 0, 0, 0})
+				continue
+			}
+			grey := uint8(255 * (v - min) / (max - min))
+			img.Set(col, row, color.RGBA{grey, grey, grey, 255})
+		}
+	}
+	return img
+}
+
+func shade(floor, ceiling, height float32) color.Color {
+	// Get height and ceiling relative to the floor.
+	height = height - floor
+	ceiling = ceiling - floor
+	shade := uint8(NUMBER_OF_SHADES-1) - uint8(height*float32(NUMBER_OF_SHADES)/ceiling)
+	if verbose {
+		log.Printf("shade %d", shade)
+	}
+	return color.Gray{shade}
+}
@@ -0,0 +1,256 @@
+// Package contour extracts iso-elevation contour lines from an EsriGrid using the marching
+// squares algorithm.
+package contour
+
+import (
+	"fmt"
+
+	"github.com/goblimey/esrigrid/model"
+)
+
+// Point is a map coordinate, in the same units as the grid's Xllcorner/Yllcorner/CellSize.
+type Point struct {
+	X, Y float32
+}
+
+// Polyline is a connected sequence of contour points.
+type Polyline []Point
+
+// Contours computes contour lines for the given grid at each of the given elevations.  Each
+// returned Polyline belongs to exactly one level; the caller can tell them apart by running
+// the cells of g through the same levels again, or by calling ContoursByLevel.
+func Contours(g model.EsriGrid, levels []float32) []Polyline {
+	var all []Polyline
+	for _, level := range levels {
+		all = append(all, contoursAtLevel(g, level)...)
+	}
+	return all
+}
+
+// LeveledPolyline is a contour polyline tagged with the elevation it traces.
+type LeveledPolyline struct {
+	Level float32
+	Line  Polyline
+}
+
+// ContoursByLevel computes contour lines for the given grid at each of the given elevations,
+// keeping track of which level produced each polyline.
+func ContoursByLevel(g model.EsriGrid, levels []float32) []LeveledPolyline {
+	var all []LeveledPolyline
+	for _, level := range levels {
+		for _, line := range contoursAtLevel(g, level) {
+			all = append(all, LeveledPolyline{Level: level, Line: line})
+		}
+	}
+	return all
+}
+
+// contoursAtLevel runs marching squares over every 2x2 window of g for a single iso-level and
+// stitches the resulting segments into polylines.
+func contoursAtLevel(g model.EsriGrid, level float32) []Polyline {
+	var segments [][2]Point
+
+	nrows := g.Nrows()
+	ncols := g.Ncols()
+	noData := g.NoDataValue()
+
+	for row := 0; row < nrows-1; row++ {
+		for col := 0; col < ncols-1; col++ {
+			topLeft := g.Height(row, col)
+			topRight := g.Height(row, col+1)
+			bottomRight := g.Height(row+1, col+1)
+			bottomLeft := g.Height(row+1, col)
+
+			if topLeft == noData || topRight == noData || bottomRight == noData || bottomLeft == noData {
+				continue
+			}
+
+			segments = append(segments, marchingSquaresCell(g, row, col, level,
+				topLeft, topRight, bottomRight, bottomLeft)...)
+		}
+	}
+
+	return stitchSegments(segments)
+}
+
+// marchingSquaresCell classifies a single 2x2 window of corners against level and returns the
+// 0, 1 or 2 line segments (each a pair of points) that cross it, per the standard marching
+// squares case table.  Corners are named clockwise from the top left: topLeft, topRight,
+// bottomRight, bottomLeft.
+func marchingSquaresCell(g model.EsriGrid, row, col int, level, topLeft, topRight, bottomRight, bottomLeft float32) [][2]Point {
+	caseIndex := 0
+	if topLeft >= level {
+		caseIndex |= 1
+	}
+	if topRight >= level {
+		caseIndex |= 2
+	}
+	if bottomRight >= level {
+		caseIndex |= 4
+	}
+	if bottomLeft >= level {
+		caseIndex |= 8
+	}
+
+	if caseIndex == 0 || caseIndex == 15 {
+		return nil
+	}
+
+	// The four corner coordinates and the point where the level crosses each edge, computed lazily.
+	topLeftPt := cellPoint(g, row, col)
+	topRightPt := cellPoint(g, row, col+1)
+	bottomRightPt := cellPoint(g, row+1, col+1)
+	bottomLeftPt := cellPoint(g, row+1, col)
+
+	top := func() Point { return edgePoint(topLeftPt, topRightPt, topLeft, topRight, level) }
+	right := func() Point { return edgePoint(topRightPt, bottomRightPt, topRight, bottomRight, level) }
+	bottom := func() Point { return edgePoint(bottomLeftPt, bottomRightPt, bottomLeft, bottomRight, level) }
+	left := func() Point { return edgePoint(topLeftPt, bottomLeftPt, topLeft, bottomLeft, level) }
+
+	switch caseIndex {
+	case 1, 14:
+		return [][2]Point{{left(), top()}}
+	case 2, 13:
+		return [][2]Point{{top(), right()}}
+	case 3, 12:
+		return [][2]Point{{left(), right()}}
+	case 4, 11:
+		return [][2]Point{{right(), bottom()}}
+	case 6, 9:
+		return [][2]Point{{top(), bottom()}}
+	case 7, 8:
+		return [][2]Point{{left(), bottom()}}
+	case 5:
+		// Saddle: topLeft and bottomRight are above level, topRight and bottomLeft below.
+		// Disambiguate using the average of the four corners as an estimate of the centre
+		// value.  If the centre is also above level, topLeft and bottomRight are connected
+		// through it and the contour isolates topRight and bottomLeft instead.
+		if (topLeft+topRight+bottomRight+bottomLeft)/4 >= level {
+			return [][2]Point{{top(), right()}, {left(), bottom()}}
+		}
+		return [][2]Point{{left(), top()}, {right(), bottom()}}
+	case 10:
+		// Saddle: topRight and bottomLeft are above level, topLeft and bottomRight below.
+		if (topLeft+topRight+bottomRight+bottomLeft)/4 >= level {
+			return [][2]Point{{left(), top()}, {right(), bottom()}}
+		}
+		return [][2]Point{{top(), right()}, {left(), bottom()}}
+	}
+
+	return nil
+}
+
+// cellPoint returns the map coordinate of the grid vertex at the given row and column.  Row 0
+// is the northernmost row and row Nrows()-1, column 0 sits at (Xllcorner, Yllcorner).
+func cellPoint(g model.EsriGrid, row, col int) Point {
+	x := g.Xllcorner() + float32(col)*g.CellSize()
+	y := g.Yllcorner() + float32(g.Nrows()-1-row)*g.CellSize()
+	return Point{X: x, Y: y}
+}
+
+// edgePoint linearly interpolates the point along the edge from p1 (height h1) to p2 (height
+// h2) where the surface crosses level.
+func edgePoint(p1, p2 Point, h1, h2, level float32) Point {
+	if h1 == h2 {
+		return p1
+	}
+	t := (level - h1) / (h2 - h1)
+	return Point{
+		X: p1.X + t*(p2.X-p1.X),
+		Y: p1.Y + t*(p2.Y-p1.Y),
+	}
+}
+
+// stitchSegments joins unordered line segments that share an endpoint into polylines, by
+// hashing each endpoint to a fixed precision and matching segments whose endpoints collide.
+func stitchSegments(segments [][2]Point) []Polyline {
+	type chain struct {
+		points []Point
+	}
+
+	key := func(p Point) string { return fmt.Sprintf("%.5f:%.5f", p.X, p.Y) }
+
+	openEnd := map[string]*chain{}
+	var chains []*chain
+
+	unlink := func(c *chain) {
+		if len(c.points) == 0 {
+			return
+		}
+		delete(openEnd, key(c.points[0]))
+		delete(openEnd, key(c.points[len(c.points)-1]))
+	}
+	link := func(c *chain) {
+		openEnd[key(c.points[0])] = c
+		openEnd[key(c.points[len(c.points)-1])] = c
+	}
+	reverse := func(points []Point) []Point {
+		reversed := make([]Point, len(points))
+		for i, p := range points {
+			reversed[len(points)-1-i] = p
+		}
+		return reversed
+	}
+
+	for _, seg := range segments {
+		p, q := seg[0], seg[1]
+		pk, qk := key(p), key(q)
+		cp, okp := openEnd[pk]
+		cq, okq := openEnd[qk]
+
+		switch {
+		case !okp && !okq:
+			c := &chain{points: []Point{p, q}}
+			chains = append(chains, c)
+			link(c)
+
+		case okp && !okq:
+			unlink(cp)
+			if key(cp.points[0]) == pk {
+				cp.points = append([]Point{q}, cp.points...)
+			} else {
+				cp.points = append(cp.points, q)
+			}
+			link(cp)
+
+		case !okp && okq:
+			unlink(cq)
+			if key(cq.points[0]) == qk {
+				cq.points = append([]Point{p}, cq.points...)
+			} else {
+				cq.points = append(cq.points, p)
+			}
+			link(cq)
+
+		case cp == cq:
+			// Both ends are already open ends of the same chain: this segment closes a loop.
+			unlink(cp)
+			if key(cp.points[len(cp.points)-1]) != pk {
+				cp.points = reverse(cp.points)
+			}
+			cp.points = append(cp.points, cp.points[0])
+
+		default:
+			// The segment joins two distinct chains into one.
+			unlink(cp)
+			unlink(cq)
+			if key(cp.points[len(cp.points)-1]) != pk {
+				cp.points = reverse(cp.points)
+			}
+			if key(cq.points[0]) != qk {
+				cq.points = reverse(cq.points)
+			}
+			cp.points = append(cp.points, cq.points...)
+			cq.points = nil
+			link(cp)
+		}
+	}
+
+	var result []Polyline
+	for _, c := range chains {
+		if len(c.points) > 0 {
+			result = append(result, Polyline(c.points))
+		}
+	}
+	return result
+}
@@ -0,0 +1,148 @@
+package contour
+
+import (
+	"testing"
+
+	"github.com/goblimey/esrigrid/model"
+)
+
+// testGrid returns a minimal grid whose georeferencing cellPoint needs; marchingSquaresCell
+// doesn't read height data through it, so no cell values need to be set.
+func testGrid() model.EsriGrid {
+	g := model.MakeEsriGrid()
+	g.SetNRows(2)
+	g.SetNCols(2)
+	g.SetXllcorner(0)
+	g.SetYllcorner(0)
+	g.SetCellSize(1)
+	return g
+}
+
+// edgeSet turns the segment list marchingSquaresCell returns into a set of unordered point
+// pairs, identified by which of the four edge crossings (top, right, bottom, left) each point
+// is - so the test can assert "this case connects left to top" without caring which element of
+// the pair comes first. The edge points are recomputed from the same corner heights and level
+// that were passed to marchingSquaresCell, exactly as its own top/right/bottom/left closures do.
+func edgeSet(t *testing.T, g model.EsriGrid, row, col int, level, topLeft, topRight, bottomRight, bottomLeft float32, segs [][2]Point) []string {
+	t.Helper()
+
+	topLeftPt := cellPoint(g, row, col)
+	topRightPt := cellPoint(g, row, col+1)
+	bottomRightPt := cellPoint(g, row+1, col+1)
+	bottomLeftPt := cellPoint(g, row+1, col)
+
+	top := edgePoint(topLeftPt, topRightPt, topLeft, topRight, level)
+	right := edgePoint(topRightPt, bottomRightPt, topRight, bottomRight, level)
+	bottom := edgePoint(bottomLeftPt, bottomRightPt, bottomLeft, bottomRight, level)
+	left := edgePoint(topLeftPt, bottomLeftPt, topLeft, bottomLeft, level)
+
+	name := func(p Point) string {
+		switch p {
+		case top:
+			return "top"
+		case right:
+			return "right"
+		case bottom:
+			return "bottom"
+		case left:
+			return "left"
+		default:
+			t.Fatalf("point %v is not one of the cell's edge crossings", p)
+			return ""
+		}
+	}
+
+	var edges []string
+	for _, seg := range segs {
+		a, b := name(seg[0]), name(seg[1])
+		if a > b {
+			a, b = b, a
+		}
+		edges = append(edges, a+"-"+b)
+	}
+	return edges
+}
+
+func TestMarchingSquaresCellCases(t *testing.T) {
+	// Corner heights are either 0 (below the iso-level, 10) or 20 (above it), so every corner
+	// unambiguously falls on one side, except for the two saddle cases which are covered
+	// separately below.
+	const level = float32(10)
+	const lo, hi = float32(0), float32(20)
+
+	tests := []struct {
+		name                                        string
+		topLeft, topRight, bottomRight, bottomLeft  float32
+		wantEdges                                   []string
+	}{
+		{"case0 all below", lo, lo, lo, lo, nil},
+		{"case1 only topLeft above", hi, lo, lo, lo, []string{"left-top"}},
+		{"case2 only topRight above", lo, hi, lo, lo, []string{"right-top"}},
+		{"case3 top edge above", hi, hi, lo, lo, []string{"left-right"}},
+		{"case4 only bottomRight above", lo, lo, hi, lo, []string{"bottom-right"}},
+		{"case6 right edge above", lo, hi, hi, lo, []string{"bottom-top"}},
+		{"case7 all but bottomLeft above", hi, hi, hi, lo, []string{"bottom-left"}},
+		{"case8 only bottomLeft above", lo, lo, lo, hi, []string{"bottom-left"}},
+		{"case9 left edge above", hi, lo, lo, hi, []string{"bottom-top"}},
+		{"case11 all but bottomRight above", hi, hi, lo, hi, []string{"bottom-right"}},
+		{"case12 bottom edge above", lo, lo, hi, hi, []string{"left-right"}},
+		{"case13 all but topRight above", hi, lo, hi, hi, []string{"right-top"}},
+		{"case14 all but topLeft above", lo, hi, hi, hi, []string{"left-top"}},
+		{"case15 all above", hi, hi, hi, hi, nil},
+	}
+
+	g := testGrid()
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			segs := marchingSquaresCell(g, 0, 0, level, tc.topLeft, tc.topRight, tc.bottomRight, tc.bottomLeft)
+			if len(segs) != len(tc.wantEdges) {
+				t.Fatalf("got %d segments %v, want %d (%v)", len(segs), segs, len(tc.wantEdges), tc.wantEdges)
+			}
+			got := edgeSet(t, g, 0, 0, level, tc.topLeft, tc.topRight, tc.bottomRight, tc.bottomLeft, segs)
+			for i, want := range tc.wantEdges {
+				if got[i] != want {
+					t.Errorf("segment %d = %q, want %q", i, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+// TestMarchingSquaresCellSaddle checks the case 5 and case 10 disambiguation, which picks
+// between the two ways of pairing up the four edge crossings based on whether the average of
+// the four corners (an estimate of the cell centre) is above or below the level.
+func TestMarchingSquaresCellSaddle(t *testing.T) {
+	const level = float32(10)
+
+	tests := []struct {
+		name                                       string
+		topLeft, topRight, bottomRight, bottomLeft float32
+		wantEdges                                  []string
+	}{
+		// Case 5: topLeft and bottomRight above level, topRight and bottomLeft below.
+		// Average (30+5+30+5)/4 = 17.5 >= level: topLeft/bottomRight connect through the centre.
+		{"case5 centre above level", 30, 5, 30, 5, []string{"right-top", "bottom-left"}},
+		// Average (11+2+11+2)/4 = 6.5 < level: topRight/bottomLeft connect through the centre.
+		{"case5 centre below level", 11, 2, 11, 2, []string{"left-top", "bottom-right"}},
+		// Case 10: topRight and bottomLeft above level, topLeft and bottomRight below.
+		{"case10 centre above level", 5, 30, 5, 30, []string{"left-top", "bottom-right"}},
+		// Average (9+11+8+11)/4 = 9.75 < level: topLeft/bottomRight connect through the centre.
+		{"case10 centre below level", 9, 11, 8, 11, []string{"right-top", "bottom-left"}},
+	}
+
+	g := testGrid()
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			segs := marchingSquaresCell(g, 0, 0, level, tc.topLeft, tc.topRight, tc.bottomRight, tc.bottomLeft)
+			if len(segs) != 2 {
+				t.Fatalf("got %d segments %v, want 2", len(segs), segs)
+			}
+			got := edgeSet(t, g, 0, 0, level, tc.topLeft, tc.topRight, tc.bottomRight, tc.bottomLeft, segs)
+			for i, want := range tc.wantEdges {
+				if got[i] != want {
+					t.Errorf("segment %d = %q, want %q", i, got[i], want)
+				}
+			}
+		})
+	}
+}
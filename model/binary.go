@@ -0,0 +1,232 @@
+package model
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// binaryHeader holds the fields of a .hdr file that accompanies a binary Esri float grid
+// (.flt) or BIL (.bil) data file.
+type binaryHeader struct {
+	ncols       int
+	nrows       int
+	xllcorner   float32
+	yllcorner   float32
+	cellsize    float32
+	noDataValue float32
+	byteOrder   binary.ByteOrder
+	nbits       int    // bits per sample - 16 or 32.  Defaults to 32 for a plain .flt file.
+	pixelType   string // "FLOAT" or "SIGNEDINT".  Defaults to "FLOAT" for a plain .flt file.
+}
+
+// readBinaryHeader reads the whitespace-delimited key/value pairs of a .hdr file.
+func readBinaryHeader(hdrPath string) (binaryHeader, error) {
+	h := binaryHeader{
+		byteOrder: binary.LittleEndian,
+		nbits:     32,
+		pixelType: "FLOAT",
+	}
+
+	in, err := os.Open(hdrPath)
+	if err != nil {
+		return h, err
+	}
+	defer in.Close()
+
+	haveNcols, haveNrows := false, false
+
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		key := strings.ToUpper(fields[0])
+		value := fields[1]
+
+		var err error
+		switch key {
+		case "NCOLS":
+			h.ncols, err = strconv.Atoi(value)
+			haveNcols = true
+		case "NROWS":
+			h.nrows, err = strconv.Atoi(value)
+			haveNrows = true
+		case "XLLCORNER":
+			var f float64
+			f, err = strconv.ParseFloat(value, 32)
+			h.xllcorner = float32(f)
+		case "YLLCORNER":
+			var f float64
+			f, err = strconv.ParseFloat(value, 32)
+			h.yllcorner = float32(f)
+		case "CELLSIZE":
+			var f float64
+			f, err = strconv.ParseFloat(value, 32)
+			h.cellsize = float32(f)
+		case "NODATA_VALUE":
+			var f float64
+			f, err = strconv.ParseFloat(value, 32)
+			h.noDataValue = float32(f)
+		case "BYTEORDER":
+			switch strings.ToUpper(value) {
+			case "MSBFIRST":
+				h.byteOrder = binary.BigEndian
+			case "LSBFIRST":
+				h.byteOrder = binary.LittleEndian
+			default:
+				return h, fmt.Errorf("%s: unrecognised BYTEORDER %q", hdrPath, value)
+			}
+		case "NBITS":
+			h.nbits, err = strconv.Atoi(value)
+		case "PIXELTYPE":
+			h.pixelType = strings.ToUpper(value)
+		}
+		if err != nil {
+			return h, fmt.Errorf("%s: invalid value for %s: %q", hdrPath, key, value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return h, err
+	}
+
+	if !haveNcols || !haveNrows {
+		return h, fmt.Errorf("%s: missing NCOLS or NROWS", hdrPath)
+	}
+
+	return h, nil
+}
+
+// hdrPathFor returns the path of the .hdr file that accompanies a binary data file.
+func hdrPathFor(path string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + ".hdr"
+}
+
+// ReadEsriBinaryFromFile reads the data from a binary Esri float grid (.flt, with a sibling
+// .hdr header) or BIL (.bil) file and sets the fields.  The payload is row-major, one sample
+// per cell, in the byte order and sample format (NBITS/PIXELTYPE) the header declares; a plain
+// .flt file without NBITS/PIXELTYPE is read as 32 bit IEEE-754 floats.
+func (ceg *ConcreteEsriGrid) ReadEsriBinaryFromFile(filename string, verbose bool) error {
+	m := "ReadEsriBinaryFromFile"
+
+	hdrPath := hdrPathFor(filename)
+	header, err := readBinaryHeader(hdrPath)
+	if err != nil {
+		return err
+	}
+	if verbose {
+		log.Printf("%s: %s ncols %d nrows %d nbits %d pixeltype %s",
+			m, hdrPath, header.ncols, header.nrows, header.nbits, header.pixelType)
+	}
+
+	bytesPerSample := header.nbits / 8
+	if bytesPerSample != 2 && bytesPerSample != 4 {
+		return fmt.Errorf("%s: unsupported NBITS %d", hdrPath, header.nbits)
+	}
+
+	in, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	ceg.ncols = header.ncols
+	ceg.nrows = header.nrows
+	ceg.xllcorner = header.xllcorner
+	ceg.yllcorner = header.yllcorner
+	ceg.cellsize = header.cellsize
+	ceg.noDataValue = header.noDataValue
+	ceg.verbose = verbose
+
+	if ceg.storeFactory == nil {
+		ceg.storeFactory = func(nrows, ncols int) HeightStore { return newSliceHeightStore(nrows, ncols) }
+	}
+	ceg.store = ceg.storeFactory(ceg.nrows, ceg.ncols)
+
+	r := bufio.NewReader(in)
+	row := make([]byte, ceg.ncols*bytesPerSample)
+
+	for rowIndex := 0; rowIndex < ceg.nrows; rowIndex++ {
+		if _, err := io.ReadFull(r, row); err != nil {
+			return fmt.Errorf("%s: reading row %d: %s", filename, rowIndex, err.Error())
+		}
+		for col := 0; col < ceg.ncols; col++ {
+			sample := row[col*bytesPerSample : (col+1)*bytesPerSample]
+			value, err := decodeSample(sample, header)
+			if err != nil {
+				return err
+			}
+			ceg.SetHeight(rowIndex, col, value)
+		}
+	}
+
+	return nil
+}
+
+// decodeSample decodes a single sample of raw bytes according to the header's byte order,
+// NBITS and PIXELTYPE, producing a height value as a float32.
+func decodeSample(sample []byte, header binaryHeader) (float32, error) {
+	switch {
+	case header.nbits == 32 && header.pixelType == "FLOAT":
+		bits := header.byteOrder.Uint32(sample)
+		return math.Float32frombits(bits), nil
+	case header.nbits == 32 && header.pixelType == "SIGNEDINT":
+		return float32(int32(header.byteOrder.Uint32(sample))), nil
+	case header.nbits == 16 && header.pixelType == "SIGNEDINT":
+		return float32(int16(header.byteOrder.Uint16(sample))), nil
+	default:
+		return 0, fmt.Errorf("unsupported combination of NBITS %d and PIXELTYPE %s", header.nbits, header.pixelType)
+	}
+}
+
+// WriteEsriBinaryToFile writes the grid to a binary Esri float grid - a .flt payload of
+// little-endian 32 bit IEEE-754 floats plus a sibling .hdr header.
+func (ceg *ConcreteEsriGrid) WriteEsriBinaryToFile(filename string) error {
+	hdrPath := hdrPathFor(filename)
+
+	hdrFile, err := os.Create(hdrPath)
+	if err != nil {
+		return err
+	}
+	defer hdrFile.Close()
+
+	fmt.Fprintf(hdrFile, "NCOLS %d\n", ceg.ncols)
+	fmt.Fprintf(hdrFile, "NROWS %d\n", ceg.nrows)
+	fmt.Fprintf(hdrFile, "XLLCORNER %f\n", ceg.xllcorner)
+	fmt.Fprintf(hdrFile, "YLLCORNER %f\n", ceg.yllcorner)
+	fmt.Fprintf(hdrFile, "CELLSIZE %f\n", ceg.cellsize)
+	fmt.Fprintf(hdrFile, "NODATA_VALUE %f\n", ceg.noDataValue)
+	fmt.Fprintf(hdrFile, "BYTEORDER LSBFIRST\n")
+
+	out, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	w := bufio.NewWriter(out)
+	buf := make([]byte, 4)
+	for row := 0; row < ceg.nrows; row++ {
+		for col := 0; col < ceg.ncols; col++ {
+			binary.LittleEndian.PutUint32(buf, math.Float32bits(ceg.store.Height(row, col)))
+			if _, err := w.Write(buf); err != nil {
+				return err
+			}
+		}
+	}
+
+	return w.Flush()
+}
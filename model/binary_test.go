@@ -0,0 +1,104 @@
+package model
+
+import (
+	"encoding/binary"
+	"path/filepath"
+	"testing"
+)
+
+// TestBinaryRoundTrip writes a small grid out as a binary Esri float grid and reads it back,
+// checking that the georeferencing and every cell's height - including a NoData cell - survive
+// the round trip unchanged.
+func TestBinaryRoundTrip(t *testing.T) {
+	fltPath := filepath.Join(t.TempDir(), "test.flt")
+
+	heights := [][]float32{
+		{1.5, 2.5, -9999},
+		{-3.25, 0, 42},
+	}
+
+	src := &ConcreteEsriGrid{
+		ncols:       3,
+		nrows:       2,
+		xllcorner:   100,
+		yllcorner:   200,
+		cellsize:    10,
+		noDataValue: -9999,
+	}
+	src.store = newSliceHeightStore(src.nrows, src.ncols)
+	for row := range heights {
+		for col := range heights[row] {
+			src.store.SetHeight(row, col, heights[row][col])
+		}
+	}
+
+	if err := src.WriteEsriBinaryToFile(fltPath); err != nil {
+		t.Fatalf("WriteEsriBinaryToFile: %v", err)
+	}
+
+	got := &ConcreteEsriGrid{}
+	if err := got.ReadEsriBinaryFromFile(fltPath, false); err != nil {
+		t.Fatalf("ReadEsriBinaryFromFile: %v", err)
+	}
+
+	if got.ncols != src.ncols || got.nrows != src.nrows {
+		t.Fatalf("dimensions = %dx%d, want %dx%d", got.nrows, got.ncols, src.nrows, src.ncols)
+	}
+	if got.xllcorner != src.xllcorner || got.yllcorner != src.yllcorner || got.cellsize != src.cellsize {
+		t.Fatalf("georeferencing = {%v %v %v}, want {%v %v %v}",
+			got.xllcorner, got.yllcorner, got.cellsize, src.xllcorner, src.yllcorner, src.cellsize)
+	}
+	if got.noDataValue != src.noDataValue {
+		t.Fatalf("noDataValue = %v, want %v", got.noDataValue, src.noDataValue)
+	}
+
+	for row := range heights {
+		for col := range heights[row] {
+			if h := got.Height(row, col); h != heights[row][col] {
+				t.Errorf("Height(%d,%d) = %v, want %v", row, col, h, heights[row][col])
+			}
+		}
+	}
+}
+
+// TestDecodeSample checks decodeSample's three supported NBITS/PIXELTYPE combinations against
+// known little-endian byte patterns.
+func TestDecodeSample(t *testing.T) {
+	tests := []struct {
+		name   string
+		header binaryHeader
+		sample []byte
+		want   float32
+	}{
+		{
+			name:   "32 bit float, little-endian",
+			header: binaryHeader{nbits: 32, pixelType: "FLOAT", byteOrder: binary.LittleEndian},
+			sample: []byte{0x00, 0x00, 0x80, 0x3f}, // 1.0 as little-endian IEEE-754
+			want:   1.0,
+		},
+		{
+			name:   "32 bit signed int, little-endian",
+			header: binaryHeader{nbits: 32, pixelType: "SIGNEDINT", byteOrder: binary.LittleEndian},
+			sample: []byte{0xff, 0xff, 0xff, 0xff}, // -1
+			want:   -1,
+		},
+		{
+			name:   "16 bit signed int, little-endian",
+			header: binaryHeader{nbits: 16, pixelType: "SIGNEDINT", byteOrder: binary.LittleEndian},
+			sample: []byte{0xff, 0xff}, // -1
+			want:   -1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := decodeSample(tc.sample, tc.header)
+			if err != nil {
+				t.Fatalf("decodeSample: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("decodeSample() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
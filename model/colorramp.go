@@ -0,0 +1,175 @@
+package model
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// rampEntry is one line of a .clr file - an elevation and the RGBA colour assigned to it.
+type rampEntry struct {
+	elevation float32
+	rgba      color.RGBA
+}
+
+// ColorRamp is a colour ramp read from a text file of the form:
+//
+//	elevation R G B [A]
+//
+// one entry per line, in any order.  Two special keywords may appear in place of the
+// elevation: "nv" gives the colour to use for cells equal to NoDataValue(), and "default"
+// gives the colour to use for heights that fall outside the range of the other entries.  If
+// A is omitted it defaults to 255 (fully opaque); the "nv" entry defaults to fully transparent
+// if it is omitted altogether.
+type ColorRamp struct {
+	entries    []rampEntry // sorted ascending by elevation
+	noData     color.RGBA
+	defaultRGBA color.RGBA
+	hasDefault bool
+}
+
+// ReadColorRampFromFile reads a colour ramp definition file and returns the ColorRamp it
+// describes.
+func ReadColorRampFromFile(filename string) (*ColorRamp, error) {
+	in, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+
+	cr := &ColorRamp{
+		noData: color.RGBA{0, 0, 0, 0},
+	}
+
+	scanner := bufio.NewScanner(in)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			return nil, fmt.Errorf("colour ramp line %d: expected at least 4 fields, got %d", lineNum, len(fields))
+		}
+
+		rgba, err := parseRampRGBA(fields[1:])
+		if err != nil {
+			return nil, fmt.Errorf("colour ramp line %d: %s", lineNum, err.Error())
+		}
+
+		switch fields[0] {
+		case "nv":
+			cr.noData = rgba
+		case "default":
+			cr.defaultRGBA = rgba
+			cr.hasDefault = true
+		default:
+			elevation, err := strconv.ParseFloat(fields[0], 32)
+			if err != nil {
+				return nil, fmt.Errorf("colour ramp line %d: invalid elevation %q", lineNum, fields[0])
+			}
+			cr.entries = append(cr.entries, rampEntry{elevation: float32(elevation), rgba: rgba})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(cr.entries, func(i, j int) bool { return cr.entries[i].elevation < cr.entries[j].elevation })
+
+	return cr, nil
+}
+
+// parseRampRGBA parses the R G B [A] fields of a colour ramp line.
+func parseRampRGBA(fields []string) (color.RGBA, error) {
+	r, err := strconv.ParseUint(fields[0], 10, 8)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("invalid R value %q", fields[0])
+	}
+	g, err := strconv.ParseUint(fields[1], 10, 8)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("invalid G value %q", fields[1])
+	}
+	b, err := strconv.ParseUint(fields[2], 10, 8)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("invalid B value %q", fields[2])
+	}
+	a := uint64(255)
+	if len(fields) > 3 {
+		a, err = strconv.ParseUint(fields[3], 10, 8)
+		if err != nil {
+			return color.RGBA{}, fmt.Errorf("invalid A value %q", fields[3])
+		}
+	}
+	return color.RGBA{uint8(r), uint8(g), uint8(b), uint8(a)}, nil
+}
+
+// Color returns the colour for the given height.  noDataValue should be the grid's
+// NoDataValue(); if height equals it the ramp's "nv" colour is returned.  If discrete is true
+// the colour of the nearest entry (by elevation) is used, otherwise the colour is linearly
+// interpolated between the two bracketing entries.
+func (cr *ColorRamp) Color(height, noDataValue float32, discrete bool) color.RGBA {
+	if height == noDataValue {
+		return cr.noData
+	}
+
+	if len(cr.entries) == 0 {
+		return cr.fallback()
+	}
+
+	if height <= cr.entries[0].elevation {
+		if height < cr.entries[0].elevation && cr.hasDefault {
+			return cr.defaultRGBA
+		}
+		return cr.entries[0].rgba
+	}
+	last := cr.entries[len(cr.entries)-1]
+	if height >= last.elevation {
+		if height > last.elevation && cr.hasDefault {
+			return cr.defaultRGBA
+		}
+		return last.rgba
+	}
+
+	// Find the bracketing pair: entries[i] <= height < entries[i+1].
+	i := sort.Search(len(cr.entries), func(i int) bool { return cr.entries[i].elevation > height }) - 1
+
+	lower := cr.entries[i]
+	upper := cr.entries[i+1]
+
+	if discrete {
+		if height-lower.elevation <= upper.elevation-height {
+			return lower.rgba
+		}
+		return upper.rgba
+	}
+
+	t := (height - lower.elevation) / (upper.elevation - lower.elevation)
+	return color.RGBA{
+		R: interpolateChannel(lower.rgba.R, upper.rgba.R, t),
+		G: interpolateChannel(lower.rgba.G, upper.rgba.G, t),
+		B: interpolateChannel(lower.rgba.B, upper.rgba.B, t),
+		A: interpolateChannel(lower.rgba.A, upper.rgba.A, t),
+	}
+}
+
+// fallback returns the colour to use when the ramp has no elevation entries at all.
+func (cr *ColorRamp) fallback() color.RGBA {
+	if cr.hasDefault {
+		return cr.defaultRGBA
+	}
+	return color.RGBA{0, 0, 0, 255}
+}
+
+// interpolateChannel linearly interpolates one 8 bit colour channel between lower and upper
+// by fraction t, which is expected to be in [0,1].
+func interpolateChannel(lower, upper uint8, t float32) uint8 {
+	return uint8(float32(lower) + t*(float32(upper)-float32(lower)))
+}
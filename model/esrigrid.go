@@ -54,6 +54,19 @@ type EsriGrid interface {
 	SetHeight(row, col int, height float32)
 	// ReadEsrigridFromFile reads the data from a plain text EsriGrid file and sets the fields.
 	ReadEsriGridFromFile(filename string, verbose bool) error
+	// WriteEsriGridToFile writes the grid to a plain text EsriGrid file in the same format
+	// that ReadEsriGridFromFile reads.
+	WriteEsriGridToFile(filename string) error
+	// ReadEsriBinaryFromFile reads the data from a binary Esri float grid (.flt, with a
+	// sibling .hdr header) or BIL file and sets the fields.
+	ReadEsriBinaryFromFile(filename string, verbose bool) error
+	// WriteEsriBinaryToFile writes the grid to a binary Esri float grid - a .flt payload of
+	// little-endian float32 values plus a sibling .hdr header.
+	WriteEsriBinaryToFile(filename string) error
+	// Close releases any resources held by the grid's underlying HeightStore.  This matters
+	// most for a grid created with MakeEsriGridWithStoreFactory and NewMmapStoreFactory, whose
+	// backing files stay mapped and on disk until Close is called.
+	Close() error
 }
 
 type ConcreteEsriGrid struct {
@@ -65,15 +78,29 @@ type ConcreteEsriGrid struct {
 	noDataValue  float32
 	maxHeight    float32
 	minHeight    float32
-	height       [][]float32	// The grid of height data
+	store        HeightStore	// The grid of height data.
+	storeFactory func(nrows, ncols int) HeightStore	// Creates store once nrows/ncols are known.
 	minHeightSet bool			// False until minHeight is set
 	maxHeightSet bool			// False until maxHeight is set.
 	verbose      bool			// Verbose logging mode.
 }
 
-// MakeEsriGrid creates and returns an EsriGrid object.
+// MakeEsriGrid creates and returns an EsriGrid object that keeps its height matrix entirely in
+// memory.
 func MakeEsriGrid() EsriGrid {
-	return &ConcreteEsriGrid{}
+	return &ConcreteEsriGrid{
+		storeFactory: func(nrows, ncols int) HeightStore { return newSliceHeightStore(nrows, ncols) },
+	}
+}
+
+// MakeEsriGridWithStoreFactory creates an EsriGrid object whose height matrix is backed by the
+// HeightStore that storeFactory creates, once the file header has been read and the grid's
+// dimensions are known.  Hillshade, Slope, Aspect, Resample and Reproject all call storeFactory
+// again to build their derived grid's store, so it must be safe to call more than once; pass
+// NewMmapStoreFactory to index grids too large to fit in RAM, rather than a closure over a
+// single NewMmapStore call, which would hand back the same backing file every time.
+func MakeEsriGridWithStoreFactory(storeFactory func(nrows, ncols int) HeightStore) EsriGrid {
+	return &ConcreteEsriGrid{storeFactory: storeFactory}
 }
 
 // NCols returns the number of columns. 
@@ -115,9 +142,9 @@ func (ceg ConcreteEsriGrid) MinHeight() float32 {
 	return ceg.minHeight
 }
 
-// Height returns the height at the intersection of a row and column 
+// Height returns the height at the intersection of a row and column
 func (ceg ConcreteEsriGrid) Height(row, col int) float32 {
-	return ceg.height[row][col]
+	return ceg.store.Height(row, col)
 }
 
 // SetNCols sets the number of columns.
@@ -157,9 +184,9 @@ func (ceg *ConcreteEsriGrid) SetHeight(row, col int, height float32) {
 		log.Printf("SetHeight(%d,%d) - row or column out of range", row, col)
 		return
 	}
-	ceg.height[row][col] = height
+	ceg.store.SetHeight(row, col, height)
 
-	if height = noDataValue {
+	if height == ceg.noDataValue {
 		return
 	}
 	if ceg.maxHeightSet {
@@ -183,6 +210,15 @@ func (ceg *ConcreteEsriGrid) SetHeight(row, col int, height float32) {
 	}
 }
 
+// Close releases any resources held by the grid's underlying HeightStore.  It is safe to call
+// on a grid whose store hasn't been created yet (for example one that failed to load).
+func (ceg *ConcreteEsriGrid) Close() error {
+	if ceg.store == nil {
+		return nil
+	}
+	return ceg.store.Close()
+}
+
 // ReadEsrigridFromFile reads the data from a plain text EsriGrid file and sets the fields.
 func (ceg *ConcreteEsriGrid) ReadEsriGridFromFile(filename string, verbose bool) error {
 	m := "ReadEsriGridFromFile"
@@ -217,144 +253,60 @@ func (ceg *ConcreteEsriGrid) ReadEsriGridFromFile(filename string, verbose bool)
 	// the bottom (most Southern) line, so the first number of the last line is the height at
 	// (xllcorner, yllcorner).
 
-	in, err := os.Open(filename)
+	header, err := peekEsriGridHeader(filename, verbose)
 	if err != nil {
-		log.Printf(filename + err.Error())
 		return err
 	}
 
-	r := bufio.NewReader(in)
+	ceg.ncols = header.Ncols
+	ceg.nrows = header.Nrows
+	ceg.xllcorner = header.Xllcorner
+	ceg.yllcorner = header.Yllcorner
+	ceg.cellsize = header.CellSize
+	ceg.noDataValue = header.NoDataValue
 
-	lineNum := 0
-	fieldName := "ncols"
-	ceg.ncols, err = readIntFromHeader(r, fieldName, verbose)
-	if err != nil {
-		return err
-	}
-	lineNum++
-	if verbose {
-		log.Printf("%s: %s %d", m, fieldName, ceg.ncols)
+	if ceg.storeFactory == nil {
+		ceg.storeFactory = func(nrows, ncols int) HeightStore { return newSliceHeightStore(nrows, ncols) }
 	}
+	ceg.store = ceg.storeFactory(ceg.nrows, ceg.ncols)
 
-	fieldName = "nrows"
-	ceg.nrows, err = readIntFromHeader(r, fieldName, verbose)
-	if err != nil {
-		return err
-	}
-	lineNum++
-	if verbose {
-		log.Printf("%s: %s %d", m, fieldName, ceg.nrows)
-	}
-
-	ceg.height = make([][]float32, ceg.nrows)
-
-	for i := 0; i < ceg.nrows; i++ {
-		ceg.height[i] = make([]float32, ceg.ncols)
-	}
-
-	fieldName = "xllcorner"
-	ceg.xllcorner, err = readFloat32FromHeader(r, fieldName, verbose)
-	if err != nil {
-		return err
-	}
-	lineNum++
-	if verbose {
-		log.Printf("%s: %s %f", m, fieldName, ceg.xllcorner)
-	}
-
-	fieldName = "yllcorner"
-	ceg.yllcorner, err = readFloat32FromHeader(r, fieldName, verbose)
-	if err != nil {
-		return err
-	}
-	lineNum++
-	if verbose {
-		log.Printf("%s: %s %f", m, fieldName, ceg.yllcorner)
-	}
+	_, err = StreamEsriGrid(filename, func(row, col int, height float32) {
+		ceg.SetHeight(row, col, height)
+	}, verbose)
 
-	fieldName = "cellsize"
-	ceg.cellsize, err = readFloat32FromHeader(r, fieldName, verbose)
-	if err != nil {
-		return err
-	}
-	lineNum++
-	if verbose {
-		log.Printf("%s: %s %f", m, fieldName, ceg.cellsize)
-	}
+	return err
+}
 
-	fieldName = "NODATA_value"
-	ceg.noDataValue, err = readFloat32FromHeader(r, fieldName, verbose)
+// WriteEsriGridToFile writes the grid to a plain text EsriGrid file in the same format that
+// ReadEsriGridFromFile reads - a six line header followed by nrows lines of ncols
+// space-separated height values.
+func (ceg *ConcreteEsriGrid) WriteEsriGridToFile(filename string) error {
+	out, err := os.Create(filename)
 	if err != nil {
 		return err
 	}
-	lineNum++
+	defer out.Close()
 
-	if verbose {
-		log.Printf("NODATA_value %f", ceg.noDataValue)
-	}
-
-	// Read nrows of lines each containing ncols floats, space separated.
-	if verbose {
-		log.Printf("%s: reading %d data lines", m, ceg.nrows)
-	}
+	w := bufio.NewWriter(out)
 
-	linesExpected := ceg.nrows + 6
+	fmt.Fprintf(w, "ncols %d\n", ceg.ncols)
+	fmt.Fprintf(w, "nrows %d\n", ceg.nrows)
+	fmt.Fprintf(w, "xllcorner %f\n", ceg.xllcorner)
+	fmt.Fprintf(w, "yllcorner %f\n", ceg.yllcorner)
+	fmt.Fprintf(w, "cellsize %f\n", ceg.cellsize)
+	fmt.Fprintf(w, "NODATA_value %f\n", ceg.noDataValue)
 
-	for row := 0; ; row++ {
-		line, err := r.ReadString('\n')
-		if err != nil {
-			break
-		}
-		lineNum++
-		if lineNum > linesExpected {
-			log.Printf("%s: warning: file %s has too many lines - expected %d\n", m, filename, linesExpected)
-			break
-		}
-		line, err = stripSpaces(line)
-		if err != nil {
-			log.Printf("%s: stripSpaces failed - %s", m, err.Error())
-			return err
-		}
-		if verbose {
-			log.Println(line)
-		}
-
-		numbers := strings.Split(line, " ")
-		if len(numbers) > ceg.ncols {
-			log.Printf("warning: line %d has too many columns - got %d expected %d\n",
-				lineNum, len(numbers), ceg.ncols)
-			continue
-		}
-		if len(numbers) < ceg.ncols {
-			log.Printf("warning: line %d has too few columns - got %d expected %d\n",
-				lineNum, len(numbers), ceg.ncols)
-			continue
-		}
-		for col := range numbers {
-			var f float32
-			_, err := fmt.Sscanf(numbers[col], "%f", &f)
-			if err != nil {
-				log.Printf("%d %d %s", row, col, err.Error())
-				return err
-			}
-
-			// Set height, maxheight and minHeight
-			ceg.SetHeight(row, col, f)
-
-			if verbose {
-				log.Printf("height[%d][%d] %f", row, col, ceg.height[row][col])
+	for row := 0; row < ceg.nrows; row++ {
+		for col := 0; col < ceg.ncols; col++ {
+			if col > 0 {
+				fmt.Fprint(w, " ")
 			}
+			fmt.Fprintf(w, "%g", ceg.store.Height(row, col))
 		}
+		fmt.Fprint(w, "\n")
 	}
 
-	if lineNum < linesExpected {
-		log.Printf("warning: file %s has too few lines - got %d expected %d\n",
-			filename, lineNum, linesExpected)
-	}
-
-	fmt.Printf("floor %f ceiling %f", ceg.maxHeight, ceg.minHeight)
-
-	return nil
+	return w.Flush()
 }
 
 func readIntFromHeader(r *bufio.Reader, fieldName string, verbose bool) (int, error) {
@@ -0,0 +1,104 @@
+package model
+
+import (
+	"bufio"
+	"encoding/binary"
+	"math"
+	"os"
+)
+
+// tiffTag is one 12 byte entry of a TIFF Image File Directory.
+type tiffTag struct {
+	id    uint16
+	typ   uint16
+	count uint32
+	value uint32 // either the value itself (if it fits in 4 bytes) or an offset to it.
+}
+
+// TIFF field types used below, per the TIFF 6.0 spec.
+const (
+	tiffTypeShort  = 3
+	tiffTypeLong   = 4
+	tiffTypeDouble = 12
+)
+
+// WriteGeoTIFF writes the grid to a single-band, 32 bit float GeoTIFF file.  Georeferencing is
+// carried by the standard GeoTIFF ModelPixelScaleTag and ModelTiepointTag tags, derived from
+// Xllcorner, Yllcorner and CellSize; no coordinate system (CRS) tag is written, since this
+// package has no built-in knowledge of coordinate systems (see CoordTransform).
+func (ceg *ConcreteEsriGrid) WriteGeoTIFF(path string) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	w := bufio.NewWriter(out)
+
+	const headerSize = 8
+	dataOffset := uint32(headerSize)
+	dataSize := uint32(ceg.nrows) * uint32(ceg.ncols) * 4
+	pixelScaleOffset := dataOffset + dataSize
+	tiepointOffset := pixelScaleOffset + 3*8
+	ifdOffset := tiepointOffset + 6*8
+
+	// Header: byte order marker, magic number 42, offset of the first IFD.
+	binary.Write(w, binary.LittleEndian, [2]byte{'I', 'I'})
+	binary.Write(w, binary.LittleEndian, uint16(42))
+	binary.Write(w, binary.LittleEndian, ifdOffset)
+
+	// Pixel data, row-major, one float32 per cell.
+	buf := make([]byte, 4)
+	for row := 0; row < ceg.nrows; row++ {
+		for col := 0; col < ceg.ncols; col++ {
+			binary.LittleEndian.PutUint32(buf, math.Float32bits(ceg.store.Height(row, col)))
+			if _, err := w.Write(buf); err != nil {
+				return err
+			}
+		}
+	}
+
+	// ModelPixelScaleTag (33550): (scaleX, scaleY, scaleZ) as doubles.
+	writeDoubles(w, []float64{float64(ceg.cellsize), float64(ceg.cellsize), 0})
+
+	// ModelTiepointTag (33922): (rasterX, rasterY, rasterZ, modelX, modelY, modelZ) as doubles.
+	// Raster (0,0) is the top left pixel, which sits at (Xllcorner, Yllcorner + Nrows*CellSize).
+	topLeftY := float64(ceg.yllcorner) + float64(ceg.nrows)*float64(ceg.cellsize)
+	writeDoubles(w, []float64{0, 0, 0, float64(ceg.xllcorner), topLeftY, 0})
+
+	tags := []tiffTag{
+		{256, tiffTypeLong, 1, uint32(ceg.ncols)},           // ImageWidth
+		{257, tiffTypeLong, 1, uint32(ceg.nrows)},           // ImageLength
+		{258, tiffTypeShort, 1, 32},                         // BitsPerSample
+		{259, tiffTypeShort, 1, 1},                          // Compression - none
+		{262, tiffTypeShort, 1, 1},                          // PhotometricInterpretation - BlackIsZero
+		{273, tiffTypeLong, 1, dataOffset},                  // StripOffsets
+		{277, tiffTypeShort, 1, 1},                          // SamplesPerPixel
+		{278, tiffTypeLong, 1, uint32(ceg.nrows)},           // RowsPerStrip
+		{279, tiffTypeLong, 1, dataSize},                    // StripByteCounts
+		{284, tiffTypeShort, 1, 1},                          // PlanarConfiguration
+		{339, tiffTypeShort, 1, 3},                          // SampleFormat - IEEE float
+		{33550, tiffTypeDouble, 3, pixelScaleOffset},        // ModelPixelScaleTag
+		{33922, tiffTypeDouble, 6, tiepointOffset},          // ModelTiepointTag
+	}
+
+	binary.Write(w, binary.LittleEndian, uint16(len(tags)))
+	for _, tag := range tags {
+		binary.Write(w, binary.LittleEndian, tag.id)
+		binary.Write(w, binary.LittleEndian, tag.typ)
+		binary.Write(w, binary.LittleEndian, tag.count)
+		binary.Write(w, binary.LittleEndian, tag.value)
+	}
+	binary.Write(w, binary.LittleEndian, uint32(0)) // no next IFD
+
+	return w.Flush()
+}
+
+// writeDoubles writes a slice of float64s to w in little-endian byte order.
+func writeDoubles(w *bufio.Writer, values []float64) {
+	buf := make([]byte, 8)
+	for _, v := range values {
+		binary.LittleEndian.PutUint64(buf, math.Float64bits(v))
+		w.Write(buf)
+	}
+}
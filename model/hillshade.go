@@ -0,0 +1,38 @@
+package model
+
+import "math"
+
+// Hillshade computes a shaded-relief grid from the receiver's height data using Horn's
+// 3x3 finite-difference algorithm, the same method used by GDAL's "hillshade" DEM processing
+// mode.  azimuthDeg is the compass bearing of the sun (0 = north, clockwise) and altitudeDeg is
+// the sun's angle above the horizon, both in degrees.  zFactor exaggerates the height values
+// relative to CellSize() and is typically 1 for grids where the height and map units match.
+//
+// The returned grid shares the receiver's georeferencing and has a shade value in [0,255] in
+// place of each height.  Edge cells and any cell whose 3x3 neighbourhood includes NoDataValue()
+// are set to NoDataValue() so that rendering code can treat them as transparent.
+func (ceg *ConcreteEsriGrid) Hillshade(azimuthDeg, altitudeDeg, zFactor float32) EsriGrid {
+	// Convert the compass azimuth to the mathematical convention used by atan2 (east = 0,
+	// anticlockwise) and work out the sun's zenith angle from its altitude.
+	azimuthRad := float64(90-azimuthDeg) * math.Pi / 180
+	zenithRad := float64(90-altitudeDeg) * math.Pi / 180
+	cosZenith := math.Cos(zenithRad)
+	sinZenith := math.Sin(zenithRad)
+
+	return ceg.deriveGrid(func(dzdx, dzdy float64) float32 {
+		slope := math.Atan(float64(zFactor) * math.Sqrt(dzdx*dzdx+dzdy*dzdy))
+		aspect := math.Atan2(dzdy, -dzdx)
+		if aspect < 0 {
+			aspect += 2 * math.Pi
+		}
+
+		shade := 255 * (cosZenith*math.Cos(slope) + sinZenith*math.Sin(slope)*math.Cos(azimuthRad-aspect))
+		if shade < 0 {
+			shade = 0
+		}
+		if shade > 255 {
+			shade = 255
+		}
+		return float32(shade)
+	})
+}
@@ -0,0 +1,101 @@
+//go:build !windows
+
+package model
+
+import (
+	"encoding/binary"
+	"log"
+	"math"
+	"os"
+	"syscall"
+)
+
+// mmapHeightStore is a HeightStore backed by a memory-mapped file on disk, so that grids
+// bigger than available RAM can still be indexed by Height/SetHeight.  Close unmaps the file
+// and deletes it, so callers should treat path as scratch space owned by the store, not as a
+// result to keep around.
+type mmapHeightStore struct {
+	file *os.File
+	path string
+	data []byte
+	ncols int
+}
+
+// NewMmapStore creates a HeightStore backed by a memory-mapped file at path, sized to hold
+// rows*cols float32 values.  The file is created (or truncated) and mapped MAP_SHARED, so
+// writes made through SetHeight are visible to anything else that maps the same file.  Call
+// Close when the store is no longer needed to unmap and remove it.
+//
+// Do not close over a fixed path and use the result as a ConcreteEsriGrid's storeFactory:
+// deriveGrid, Resample and Reproject all call storeFactory again to build the derived grid's
+// store, and a second call with the same path truncates the source grid's backing file out
+// from under it. Use NewMmapStoreFactory instead, which mints a fresh path on every call.
+func NewMmapStore(path string, rows, cols int) (HeightStore, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	size := int64(rows) * int64(cols) * 4
+	if err := file.Truncate(size); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	data, err := syscall.Mmap(int(file.Fd()), 0, int(size), syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &mmapHeightStore{file: file, path: path, data: data, ncols: cols}, nil
+}
+
+// Height returns the height at the intersection of a row and column.
+func (s *mmapHeightStore) Height(row, col int) float32 {
+	offset := (row*s.ncols + col) * 4
+	return math.Float32frombits(binary.LittleEndian.Uint32(s.data[offset : offset+4]))
+}
+
+// SetHeight sets the height at the intersection of a row and column.
+func (s *mmapHeightStore) SetHeight(row, col int, height float32) {
+	offset := (row*s.ncols + col) * 4
+	binary.LittleEndian.PutUint32(s.data[offset:offset+4], math.Float32bits(height))
+}
+
+// Close unmaps the backing file, closes it and removes it from disk.
+func (s *mmapHeightStore) Close() error {
+	if err := syscall.Munmap(s.data); err != nil {
+		s.file.Close()
+		return err
+	}
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+	return os.Remove(s.path)
+}
+
+// NewMmapStoreFactory returns a storeFactory (suitable for MakeEsriGridWithStoreFactory) that
+// backs every store it creates with its own mmap-ed file in dir, named uniquely so that a
+// source grid and any grids derived from it (by Hillshade, Slope, Aspect, Resample or
+// Reproject) never collide on the same backing file.  If a unique file can't be created or
+// mapped, it logs the error and falls back to an in-memory store rather than returning nil.
+func NewMmapStoreFactory(dir string) func(nrows, ncols int) HeightStore {
+	return func(nrows, ncols int) HeightStore {
+		f, err := os.CreateTemp(dir, "esrigrid-*.flt")
+		if err != nil {
+			log.Printf("NewMmapStoreFactory: creating backing file in %s: %s; falling back to an in-memory store", dir, err)
+			return newSliceHeightStore(nrows, ncols)
+		}
+		path := f.Name()
+		f.Close()
+
+		store, err := NewMmapStore(path, nrows, ncols)
+		if err != nil {
+			log.Printf("NewMmapStoreFactory: mapping %s: %s; falling back to an in-memory store", path, err)
+			os.Remove(path)
+			return newSliceHeightStore(nrows, ncols)
+		}
+		return store
+	}
+}
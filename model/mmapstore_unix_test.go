@@ -0,0 +1,99 @@
+//go:build !windows
+
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestAsciiGrid writes a minimal 3x3 Esri ASCII grid to dir, matching the format
+// ReadEsriGridFromFile reads, and returns its path.
+func writeTestAsciiGrid(t *testing.T, dir string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, "source.asc")
+	contents := "ncols 3\n" +
+		"nrows 3\n" +
+		"xllcorner 0\n" +
+		"yllcorner 0\n" +
+		"cellsize 1\n" +
+		"NODATA_value -9999\n" +
+		"10 20 10\n" +
+		"20 40 20\n" +
+		"10 20 10\n"
+
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatalf("writing %s: %v", path, err)
+	}
+	return path
+}
+
+// snapshotHeights reads every cell of g into a [][]float32.
+func snapshotHeights(g EsriGrid) [][]float32 {
+	heights := make([][]float32, g.Nrows())
+	for row := range heights {
+		heights[row] = make([]float32, g.Ncols())
+		for col := range heights[row] {
+			heights[row][col] = g.Height(row, col)
+		}
+	}
+	return heights
+}
+
+func assertHeightsUnchanged(t *testing.T, g EsriGrid, want [][]float32) {
+	t.Helper()
+	for row := range want {
+		for col := range want[row] {
+			if got := g.Height(row, col); got != want[row][col] {
+				t.Errorf("source Height(%d,%d) = %v, want %v (unchanged)", row, col, got, want[row][col])
+			}
+		}
+	}
+}
+
+// TestMmapStoreFactorySurvivesDerive checks that deriving a grid (via Slope, which goes
+// through deriveGrid, and via Resample) from a source loaded through NewMmapStoreFactory
+// doesn't corrupt the source grid's backing file - the regression covered here is that
+// storeFactory gets called again to build the derived grid's store, and a factory that always
+// returned the same backing path would truncate the source's file out from under it.
+func TestMmapStoreFactorySurvivesDerive(t *testing.T) {
+	t.Run("Slope", func(t *testing.T) {
+		dir := t.TempDir()
+		asciiPath := writeTestAsciiGrid(t, dir)
+
+		g := MakeEsriGridWithStoreFactory(NewMmapStoreFactory(dir))
+		if err := g.ReadEsriGridFromFile(asciiPath, false); err != nil {
+			t.Fatalf("ReadEsriGridFromFile: %v", err)
+		}
+		defer g.Close()
+
+		want := snapshotHeights(g)
+
+		ceg := g.(*ConcreteEsriGrid)
+		derived := ceg.Slope(1)
+		defer derived.Close()
+
+		assertHeightsUnchanged(t, g, want)
+	})
+
+	t.Run("Resample", func(t *testing.T) {
+		dir := t.TempDir()
+		asciiPath := writeTestAsciiGrid(t, dir)
+
+		g := MakeEsriGridWithStoreFactory(NewMmapStoreFactory(dir))
+		if err := g.ReadEsriGridFromFile(asciiPath, false); err != nil {
+			t.Fatalf("ReadEsriGridFromFile: %v", err)
+		}
+		defer g.Close()
+
+		want := snapshotHeights(g)
+
+		ceg := g.(*ConcreteEsriGrid)
+		derived := ceg.Resample(2, Bilinear)
+		defer derived.Close()
+
+		assertHeightsUnchanged(t, g, want)
+	})
+}
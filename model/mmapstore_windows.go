@@ -0,0 +1,24 @@
+//go:build windows
+
+package model
+
+import (
+	"errors"
+	"log"
+)
+
+// NewMmapStore creates a HeightStore backed by a memory-mapped file.  It isn't implemented on
+// Windows yet; use the default in-memory store (MakeEsriGrid) or StreamEsriGrid instead.
+func NewMmapStore(path string, rows, cols int) (HeightStore, error) {
+	return nil, errors.New("NewMmapStore is not implemented on Windows")
+}
+
+// NewMmapStoreFactory returns a storeFactory (suitable for MakeEsriGridWithStoreFactory) that
+// would back every store it creates with its own mmap-ed file in dir. Since NewMmapStore isn't
+// implemented on Windows yet, it logs that and falls back to an in-memory store instead.
+func NewMmapStoreFactory(dir string) func(nrows, ncols int) HeightStore {
+	return func(nrows, ncols int) HeightStore {
+		log.Printf("NewMmapStoreFactory: mmap-backed stores are not implemented on Windows; falling back to an in-memory store")
+		return newSliceHeightStore(nrows, ncols)
+	}
+}
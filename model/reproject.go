@@ -0,0 +1,218 @@
+package model
+
+import "math"
+
+// ResampleMethod selects the interpolation kernel used by Resample.
+type ResampleMethod int
+
+const (
+	// Nearest picks the value of the single closest source cell.
+	Nearest ResampleMethod = iota
+	// Bilinear interpolates linearly between the four surrounding source cells.
+	Bilinear
+	// Bicubic interpolates using a cubic convolution kernel over the surrounding 4x4 source
+	// cells, giving a smoother result than Bilinear at the cost of more computation.
+	Bicubic
+)
+
+// Resample returns a new grid covering the same extent as the receiver but with a different
+// cell size, interpolated using the given method.  If any source cell that contributes to an
+// output cell is NoDataValue(), that output cell is also set to NoDataValue().
+func (ceg *ConcreteEsriGrid) Resample(newCellSize float32, method ResampleMethod) EsriGrid {
+	width := float32(ceg.ncols) * ceg.cellsize
+	height := float32(ceg.nrows) * ceg.cellsize
+
+	newNcols := int(math.Round(float64(width / newCellSize)))
+	newNrows := int(math.Round(float64(height / newCellSize)))
+
+	result := &ConcreteEsriGrid{
+		ncols:        newNcols,
+		nrows:        newNrows,
+		xllcorner:    ceg.xllcorner,
+		yllcorner:    ceg.yllcorner,
+		cellsize:     newCellSize,
+		noDataValue:  ceg.noDataValue,
+		verbose:      ceg.verbose,
+		storeFactory: ceg.storeFactory,
+	}
+	result.store = ceg.newDerivedStore(newNrows, newNcols)
+
+	for row := 0; row < newNrows; row++ {
+		for col := 0; col < newNcols; col++ {
+			x := result.xllcorner + float32(col)*newCellSize
+			y := result.yllcorner + float32(newNrows-1-row)*newCellSize
+
+			colFrac := float64((x - ceg.xllcorner) / ceg.cellsize)
+			rowFrac := float64(ceg.nrows-1) - float64((y-ceg.yllcorner)/ceg.cellsize)
+
+			value, ok := ceg.sample(rowFrac, colFrac, method)
+			if !ok {
+				result.SetHeight(row, col, ceg.noDataValue)
+				continue
+			}
+			result.SetHeight(row, col, value)
+		}
+	}
+
+	return result
+}
+
+// sample interpolates the receiver's height at fractional (rowFrac, colFrac) using method.  It
+// returns ok=false if any source cell that contributes to the result is out of range or equal
+// to NoDataValue().
+func (ceg *ConcreteEsriGrid) sample(rowFrac, colFrac float64, method ResampleMethod) (float32, bool) {
+	switch method {
+	case Bilinear:
+		return ceg.sampleBilinear(rowFrac, colFrac)
+	case Bicubic:
+		return ceg.sampleBicubic(rowFrac, colFrac)
+	default:
+		return ceg.sampleNearest(rowFrac, colFrac)
+	}
+}
+
+func (ceg *ConcreteEsriGrid) sampleNearest(rowFrac, colFrac float64) (float32, bool) {
+	row := int(math.Round(rowFrac))
+	col := int(math.Round(colFrac))
+	if row < 0 || row >= ceg.nrows || col < 0 || col >= ceg.ncols {
+		return 0, false
+	}
+	v := ceg.Height(row, col)
+	if v == ceg.noDataValue {
+		return 0, false
+	}
+	return v, true
+}
+
+func (ceg *ConcreteEsriGrid) sampleBilinear(rowFrac, colFrac float64) (float32, bool) {
+	r0 := int(math.Floor(rowFrac))
+	c0 := int(math.Floor(colFrac))
+	r1, c1 := r0+1, c0+1
+
+	if r0 < 0 || c0 < 0 || r1 >= ceg.nrows || c1 >= ceg.ncols {
+		return 0, false
+	}
+
+	v00, v01 := ceg.Height(r0, c0), ceg.Height(r0, c1)
+	v10, v11 := ceg.Height(r1, c0), ceg.Height(r1, c1)
+	if v00 == ceg.noDataValue || v01 == ceg.noDataValue || v10 == ceg.noDataValue || v11 == ceg.noDataValue {
+		return 0, false
+	}
+
+	tr := rowFrac - float64(r0)
+	tc := colFrac - float64(c0)
+
+	top := float64(v00) + tc*(float64(v01)-float64(v00))
+	bottom := float64(v10) + tc*(float64(v11)-float64(v10))
+	return float32(top + tr*(bottom-top)), true
+}
+
+func (ceg *ConcreteEsriGrid) sampleBicubic(rowFrac, colFrac float64) (float32, bool) {
+	r1 := int(math.Floor(rowFrac))
+	c1 := int(math.Floor(colFrac))
+
+	if r1-1 < 0 || c1-1 < 0 || r1+2 >= ceg.nrows || c1+2 >= ceg.ncols {
+		return 0, false
+	}
+
+	tr := rowFrac - float64(r1)
+	tc := colFrac - float64(c1)
+
+	var rowValues [4]float64
+	for i := -1; i <= 2; i++ {
+		var samples [4]float64
+		for j := -1; j <= 2; j++ {
+			v := ceg.Height(r1+i, c1+j)
+			if v == ceg.noDataValue {
+				return 0, false
+			}
+			samples[j+1] = float64(v)
+		}
+		rowValues[i+1] = cubicInterpolate(samples, tc)
+	}
+
+	return float32(cubicInterpolate(rowValues, tr)), true
+}
+
+// cubicInterpolate evaluates a Catmull-Rom cubic convolution kernel through p[0..3], which are
+// samples at positions -1, 0, 1, 2, at fractional position t in [0,1] between p[1] and p[2].
+func cubicInterpolate(p [4]float64, t float64) float64 {
+	return p[1] + 0.5*t*(p[2]-p[0]+t*(2*p[0]-5*p[1]+4*p[2]-p[3]+t*(3*(p[1]-p[2])+p[3]-p[0])))
+}
+
+// CoordTransform converts map coordinates between the grid's current CRS and a target CRS.  It
+// is the extension point Reproject uses instead of a hard dependency on a particular projection
+// library - callers that need real coordinate system support can implement it on top of
+// whichever library they prefer (for example github.com/twpayne/go-proj).
+type CoordTransform interface {
+	// Forward converts a point from the grid's current CRS to the target CRS.
+	Forward(x, y float64) (float64, float64, error)
+	// Inverse converts a point from the target CRS back to the grid's current CRS.
+	Inverse(x, y float64) (float64, float64, error)
+}
+
+// Reproject warps the receiver into a new grid in the CRS that transform's Forward/Inverse
+// methods convert to and from.  targetEPSG is recorded for the caller's reference only - this
+// package has no built-in knowledge of coordinate systems - and the new grid keeps the
+// receiver's nominal cell size.  Any output cell whose source location (found via
+// transform.Inverse) falls outside the receiver's extent, or on a NoData cell, is set to
+// NoDataValue().
+func (ceg *ConcreteEsriGrid) Reproject(targetEPSG int, transform CoordTransform) (EsriGrid, error) {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+
+	corners := [][2]float32{
+		{ceg.xllcorner, ceg.yllcorner},
+		{ceg.xllcorner + float32(ceg.ncols)*ceg.cellsize, ceg.yllcorner},
+		{ceg.xllcorner, ceg.yllcorner + float32(ceg.nrows)*ceg.cellsize},
+		{ceg.xllcorner + float32(ceg.ncols)*ceg.cellsize, ceg.yllcorner + float32(ceg.nrows)*ceg.cellsize},
+	}
+	for _, corner := range corners {
+		x, y, err := transform.Forward(float64(corner[0]), float64(corner[1]))
+		if err != nil {
+			return nil, err
+		}
+		minX, maxX = math.Min(minX, x), math.Max(maxX, x)
+		minY, maxY = math.Min(minY, y), math.Max(maxY, y)
+	}
+
+	newCellSize := ceg.cellsize
+	newNcols := int(math.Ceil((maxX - minX) / float64(newCellSize)))
+	newNrows := int(math.Ceil((maxY - minY) / float64(newCellSize)))
+
+	result := &ConcreteEsriGrid{
+		ncols:        newNcols,
+		nrows:        newNrows,
+		xllcorner:    float32(minX),
+		yllcorner:    float32(minY),
+		cellsize:     newCellSize,
+		noDataValue:  ceg.noDataValue,
+		verbose:      ceg.verbose,
+		storeFactory: ceg.storeFactory,
+	}
+	result.store = ceg.newDerivedStore(newNrows, newNcols)
+
+	for row := 0; row < newNrows; row++ {
+		for col := 0; col < newNcols; col++ {
+			x := minX + float64(col)*float64(newCellSize)
+			y := minY + float64(newNrows-1-row)*float64(newCellSize)
+
+			srcX, srcY, err := transform.Inverse(x, y)
+			if err != nil {
+				return nil, err
+			}
+
+			colFrac := (srcX - float64(ceg.xllcorner)) / float64(ceg.cellsize)
+			rowFrac := float64(ceg.nrows-1) - (srcY-float64(ceg.yllcorner))/float64(ceg.cellsize)
+
+			value, ok := ceg.sampleNearest(rowFrac, colFrac)
+			if !ok {
+				result.SetHeight(row, col, ceg.noDataValue)
+				continue
+			}
+			result.SetHeight(row, col, value)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,90 @@
+package model
+
+import "math"
+
+// Slope computes a derived grid giving the slope, in degrees, at each cell of the receiver's
+// height data, using Horn's 3x3 finite-difference weights (the same neighbourhood used by
+// Hillshade).  zFactor exaggerates the height values relative to CellSize() and is typically 1
+// where the height and map units match.
+//
+// The returned grid shares the receiver's georeferencing.  Edge cells and any cell whose 3x3
+// neighbourhood includes NoDataValue() are set to NoDataValue().
+func (ceg *ConcreteEsriGrid) Slope(zFactor float32) EsriGrid {
+	return ceg.deriveGrid(func(dzdx, dzdy float64) float32 {
+		slope := math.Atan(float64(zFactor) * math.Sqrt(dzdx*dzdx+dzdy*dzdy))
+		return float32(slope * 180 / math.Pi)
+	})
+}
+
+// Aspect computes a derived grid giving the aspect, in degrees clockwise from north, at each
+// cell of the receiver's height data, using Horn's 3x3 finite-difference weights.  Flat cells
+// (zero gradient in both directions) are given an aspect of -1, matching the convention used by
+// GDAL's DEM processing tools.
+//
+// The returned grid shares the receiver's georeferencing.  Edge cells and any cell whose 3x3
+// neighbourhood includes NoDataValue() are set to NoDataValue().
+func (ceg *ConcreteEsriGrid) Aspect() EsriGrid {
+	return ceg.deriveGrid(func(dzdx, dzdy float64) float32 {
+		if dzdx == 0 && dzdy == 0 {
+			return -1
+		}
+		aspect := math.Atan2(dzdy, -dzdx) * 180 / math.Pi
+		// Convert from mathematical convention (east = 0, anticlockwise) to compass bearing
+		// (north = 0, clockwise).
+		aspect = 90 - aspect
+		if aspect < 0 {
+			aspect += 360
+		}
+		return float32(aspect)
+	})
+}
+
+// deriveGrid builds a new grid sharing the receiver's georeferencing, applying combine to the
+// Horn's-weights east-west and north-south gradients at each interior cell.  Edge cells, NoData
+// cells and cells with a NoData neighbour are set to NoDataValue().
+func (ceg *ConcreteEsriGrid) deriveGrid(combine func(dzdx, dzdy float64) float32) EsriGrid {
+	result := &ConcreteEsriGrid{
+		ncols:        ceg.ncols,
+		nrows:        ceg.nrows,
+		xllcorner:    ceg.xllcorner,
+		yllcorner:    ceg.yllcorner,
+		cellsize:     ceg.cellsize,
+		noDataValue:  ceg.noDataValue,
+		verbose:      ceg.verbose,
+		storeFactory: ceg.storeFactory,
+	}
+	result.store = ceg.newDerivedStore(ceg.nrows, ceg.ncols)
+
+	for row := 0; row < ceg.nrows; row++ {
+		for col := 0; col < ceg.ncols; col++ {
+			if row == 0 || row == ceg.nrows-1 || col == 0 || col == ceg.ncols-1 {
+				result.SetHeight(row, col, ceg.noDataValue)
+				continue
+			}
+
+			a := ceg.Height(row-1, col-1)
+			b := ceg.Height(row-1, col)
+			c := ceg.Height(row-1, col+1)
+			d := ceg.Height(row, col-1)
+			e := ceg.Height(row, col)
+			f := ceg.Height(row, col+1)
+			g := ceg.Height(row+1, col-1)
+			h := ceg.Height(row+1, col)
+			i := ceg.Height(row+1, col+1)
+
+			if a == ceg.noDataValue || b == ceg.noDataValue || c == ceg.noDataValue ||
+				d == ceg.noDataValue || e == ceg.noDataValue || f == ceg.noDataValue || g == ceg.noDataValue ||
+				h == ceg.noDataValue || i == ceg.noDataValue {
+				result.SetHeight(row, col, ceg.noDataValue)
+				continue
+			}
+
+			dzdx := float64((c+2*f+i)-(a+2*d+g)) / (8 * float64(ceg.cellsize))
+			dzdy := float64((g+2*h+i)-(a+2*b+c)) / (8 * float64(ceg.cellsize))
+
+			result.SetHeight(row, col, combine(dzdx, dzdy))
+		}
+	}
+
+	return result
+}
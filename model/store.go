@@ -0,0 +1,58 @@
+package model
+
+// HeightStore is the pluggable backing store for a grid's height matrix.  ConcreteEsriGrid
+// defaults to an in-memory implementation that holds every cell in a slice of slices, but a
+// HeightStore can equally be backed by something that doesn't require the whole grid to live in
+// RAM at once - see NewMmapStore.
+type HeightStore interface {
+	// Height returns the height at the intersection of a row and column.
+	Height(row, col int) float32
+	// SetHeight sets the height at the intersection of a row and column.
+	SetHeight(row, col int, height float32)
+	// Close releases any resources the store holds open.  The default in-memory store has
+	// nothing to release; a store backed by a file (see NewMmapStore) unmaps and removes it.
+	Close() error
+}
+
+// sliceHeightStore is the default HeightStore - a plain slice of slices held entirely in
+// memory.
+type sliceHeightStore [][]float32
+
+// newSliceHeightStore creates a sliceHeightStore sized for the given number of rows and
+// columns.
+func newSliceHeightStore(nrows, ncols int) sliceHeightStore {
+	rows := make(sliceHeightStore, nrows)
+	for i := range rows {
+		rows[i] = make([]float32, ncols)
+	}
+	return rows
+}
+
+// Height returns the height at the intersection of a row and column.
+func (s sliceHeightStore) Height(row, col int) float32 {
+	return s[row][col]
+}
+
+// SetHeight sets the height at the intersection of a row and column.
+func (s sliceHeightStore) SetHeight(row, col int, height float32) {
+	s[row][col] = height
+}
+
+// Close is a no-op - a sliceHeightStore holds nothing but Go-managed memory.
+func (s sliceHeightStore) Close() error {
+	return nil
+}
+
+// newDerivedStore creates a HeightStore for a grid derived from ceg (by Hillshade, Slope,
+// Aspect, Resample or Reproject), sized for nrows x ncols.  It reuses ceg's storeFactory so
+// that, for example, resampling a grid backed by NewMmapStoreFactory doesn't silently pull the
+// whole result back into RAM; a receiver with no storeFactory (only possible via a zero-value
+// ConcreteEsriGrid rather than one returned by MakeEsriGrid) falls back to an in-memory store.
+// storeFactory is called once per derived grid in addition to the call that built ceg's own
+// store, so it must be safe to invoke repeatedly - see NewMmapStoreFactory.
+func (ceg *ConcreteEsriGrid) newDerivedStore(nrows, ncols int) HeightStore {
+	if ceg.storeFactory != nil {
+		return ceg.storeFactory(nrows, ncols)
+	}
+	return newSliceHeightStore(nrows, ncols)
+}
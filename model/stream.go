@@ -0,0 +1,122 @@
+package model
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Header holds the six header fields of an Esri ASCII grid file.
+type Header struct {
+	Ncols       int
+	Nrows       int
+	Xllcorner   float32
+	Yllcorner   float32
+	CellSize    float32
+	NoDataValue float32
+}
+
+// CellVisitor is called once for every cell read by StreamEsriGrid, in row-major order.
+type CellVisitor func(row, col int, height float32)
+
+// StreamEsriGrid parses the header of an Esri ASCII grid file and then reads the data one row
+// at a time, calling visit for every cell, without ever allocating the full [nrows][ncols]
+// height matrix.  It reuses a single row buffer across rows, and is therefore safe to use on
+// grids far bigger than available RAM as long as the caller's visit function doesn't retain the
+// whole grid itself.
+func StreamEsriGrid(filename string, visit CellVisitor, verbose bool) (Header, error) {
+	in, err := os.Open(filename)
+	if err != nil {
+		return Header{}, err
+	}
+	defer in.Close()
+
+	r := bufio.NewReader(in)
+
+	header, err := readHeader(r, verbose)
+	if err != nil {
+		return header, err
+	}
+
+	row := make([]float32, header.Ncols)
+
+	// bufio.Scanner's default split function, ScanLines, copes with a final line that has no
+	// trailing newline, unlike the bufio.Reader.ReadString('\n') this code used to use.
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), bufio.MaxScanTokenSize)
+
+	for rowIndex := 0; rowIndex < header.Nrows; rowIndex++ {
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return header, err
+			}
+			return header, fmt.Errorf("%s: expected %d data rows, found %d", filename, header.Nrows, rowIndex)
+		}
+
+		line, err := stripSpaces(scanner.Text())
+		if err != nil {
+			return header, err
+		}
+
+		fields := strings.Split(line, " ")
+		if len(fields) != header.Ncols {
+			return header, fmt.Errorf("%s: row %d has %d columns, expected %d",
+				filename, rowIndex, len(fields), header.Ncols)
+		}
+
+		for col, field := range fields {
+			v, err := strconv.ParseFloat(field, 32)
+			if err != nil {
+				return header, fmt.Errorf("%s: row %d col %d: %s", filename, rowIndex, col, err.Error())
+			}
+			row[col] = float32(v)
+		}
+
+		for col := 0; col < header.Ncols; col++ {
+			visit(rowIndex, col, row[col])
+		}
+	}
+
+	return header, nil
+}
+
+// readHeader reads the six header fields of an Esri ASCII grid file from r.
+func readHeader(r *bufio.Reader, verbose bool) (Header, error) {
+	var header Header
+	var err error
+
+	if header.Ncols, err = readIntFromHeader(r, "ncols", verbose); err != nil {
+		return header, err
+	}
+	if header.Nrows, err = readIntFromHeader(r, "nrows", verbose); err != nil {
+		return header, err
+	}
+	if header.Xllcorner, err = readFloat32FromHeader(r, "xllcorner", verbose); err != nil {
+		return header, err
+	}
+	if header.Yllcorner, err = readFloat32FromHeader(r, "yllcorner", verbose); err != nil {
+		return header, err
+	}
+	if header.CellSize, err = readFloat32FromHeader(r, "cellsize", verbose); err != nil {
+		return header, err
+	}
+	if header.NoDataValue, err = readFloat32FromHeader(r, "NODATA_value", verbose); err != nil {
+		return header, err
+	}
+
+	return header, nil
+}
+
+// peekEsriGridHeader reads just the header of an Esri ASCII grid file, without reading any of
+// its row data.
+func peekEsriGridHeader(filename string, verbose bool) (Header, error) {
+	in, err := os.Open(filename)
+	if err != nil {
+		return Header{}, err
+	}
+	defer in.Close()
+
+	return readHeader(bufio.NewReader(in), verbose)
+}